@@ -0,0 +1,50 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package algorithm defines the interface implemented by every criticality
+// scoring algorithm, and a registry used to look algorithms up by the name
+// used in a scorer config file's `algorithm:` field.
+package algorithm
+
+import "fmt"
+
+// Algorithm computes a criticality score for a single record of signals.
+type Algorithm interface {
+	// Score returns the criticality score for record, or an error if record
+	// can't be scored, e.g. because a required field is missing.
+	Score(record map[string]float64) (float64, error)
+}
+
+// Factory builds an Algorithm, using unmarshal to decode the whole config
+// file into whatever structure the algorithm requires.
+type Factory func(unmarshal func(interface{}) error) (Algorithm, error)
+
+var registry = make(map[string]Factory)
+
+// Register associates name with factory, so that a config file with
+// `algorithm: <name>` constructs an Algorithm via factory. Register is
+// expected to be called from an algorithm package's init(), and panics if
+// name has already been registered.
+func Register(name string, factory Factory) {
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("algorithm: Register called twice for algorithm %q", name))
+	}
+	registry[name] = factory
+}
+
+// Get returns the Factory registered for name, and true if one exists.
+func Get(name string) (Factory, bool) {
+	factory, ok := registry[name]
+	return factory, ok
+}
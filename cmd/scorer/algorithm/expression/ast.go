@@ -0,0 +1,119 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+// node is a single node in the expression's abstract syntax tree.
+type node interface {
+	// eval evaluates the node against env.
+	eval(env *evalEnv) (float64, error)
+	// identifiers appends every field name referenced by the node (and
+	// its descendants) to out.
+	identifiers(out map[string]bool)
+}
+
+type numberNode float64
+
+func (n numberNode) eval(*evalEnv) (float64, error) { return float64(n), nil }
+func (numberNode) identifiers(map[string]bool)      {}
+
+type identNode string
+
+func (n identNode) eval(env *evalEnv) (float64, error) {
+	return env.lookup(string(n))
+}
+
+func (n identNode) identifiers(out map[string]bool) {
+	out[string(n)] = true
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+func (n unaryNode) eval(env *evalEnv) (float64, error) {
+	x, err := n.x.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "-" {
+		return -x, nil
+	}
+	return x, nil
+}
+
+func (n unaryNode) identifiers(out map[string]bool) { n.x.identifiers(out) }
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(env *evalEnv) (float64, error) {
+	l, err := n.left.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.right.eval(env)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		return l / r, nil
+	case "^":
+		return pow(l, r), nil
+	default:
+		panic("expression: unknown operator " + n.op)
+	}
+}
+
+func (n binaryNode) identifiers(out map[string]bool) {
+	n.left.identifiers(out)
+	n.right.identifiers(out)
+}
+
+type callNode struct {
+	name string
+	args []node
+}
+
+func (n callNode) eval(env *evalEnv) (float64, error) {
+	fn, ok := functions[n.name]
+	if !ok {
+		return 0, &EvalError{Msg: "unknown function " + n.name}
+	}
+	args := make([]float64, len(n.args))
+	for i, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return 0, err
+		}
+		args[i] = v
+	}
+	return fn(args)
+}
+
+func (n callNode) identifiers(out map[string]bool) {
+	for _, a := range n.args {
+		a.identifiers(out)
+	}
+}
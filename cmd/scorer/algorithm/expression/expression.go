@@ -0,0 +1,159 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expression implements a HIL-style "expression" scoring
+// algorithm, letting a config file define the scoring formula directly
+// instead of picking from a fixed distribution list, e.g.:
+//
+//	algorithm: expression
+//	expression: "0.4*log(1+contributors) + 0.6*clamp(recent_commits/100,0,1)"
+//
+// An expression is tokenized, parsed into an AST once at config-load
+// time, and evaluated against each record's signals. Available functions
+// are log, exp, min, max, clamp, zipf and linear.
+package expression
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ossf/criticality_score/cmd/scorer/algorithm"
+)
+
+func init() {
+	algorithm.Register("expression", New)
+}
+
+// OnMissing controls how a missing field is resolved when evaluating an
+// expression.
+type OnMissing string
+
+const (
+	// OnMissingZero resolves a missing field to 0. This is the default.
+	OnMissingZero OnMissing = "zero"
+	// OnMissingError causes Score to return an error if a field is
+	// missing. Used when a missing signal indicates a data problem that
+	// should stop the run rather than silently skew the score.
+	OnMissingError OnMissing = "error"
+	// OnMissingNaN resolves a missing field to NaN, propagating through
+	// the expression so the resulting score is also NaN.
+	OnMissingNaN OnMissing = "nan"
+)
+
+// Config is the config file fields used by `algorithm: expression`.
+type Config struct {
+	Expression string    `yaml:"expression"`
+	OnMissing  OnMissing `yaml:"on_missing"`
+}
+
+// EvalError is returned when an expression fails to evaluate, e.g. when
+// on_missing is "error" and a referenced field isn't present in a record.
+type EvalError struct {
+	Msg string
+}
+
+func (e *EvalError) Error() string {
+	return e.Msg
+}
+
+// expr is an Algorithm that scores a record by evaluating a parsed
+// expression against it.
+type expr struct {
+	ast       node
+	onMissing OnMissing
+}
+
+// New constructs the expression Algorithm, decoding its config via
+// unmarshal. The expression is parsed immediately, and dry-run against a
+// synthetic record built from every field it references, so that a
+// malformed formula is rejected at config-load time rather than on the
+// first scored row.
+func New(unmarshal func(interface{}) error) (algorithm.Algorithm, error) {
+	var c Config
+	if err := unmarshal(&c); err != nil {
+		return nil, err
+	}
+	if c.Expression == "" {
+		return nil, fmt.Errorf("expression: the \"expression\" field is required")
+	}
+	onMissing := c.OnMissing
+	if onMissing == "" {
+		onMissing = OnMissingZero
+	}
+	switch onMissing {
+	case OnMissingZero, OnMissingError, OnMissingNaN:
+	default:
+		return nil, fmt.Errorf("expression: unknown on_missing value %q", onMissing)
+	}
+
+	ast, err := parse(c.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("expression: parsing %q: %w", c.Expression, err)
+	}
+	e := &expr{ast: ast, onMissing: onMissing}
+
+	if err := e.dryRun(); err != nil {
+		return nil, fmt.Errorf("expression: validating %q: %w", c.Expression, err)
+	}
+	return e, nil
+}
+
+// dryRun evaluates the expression against a synthetic record that
+// supplies every field the expression references, catching mistakes that
+// parsing alone doesn't (unknown functions, wrong arity) without needing
+// real input data.
+func (e *expr) dryRun() (err error) {
+	fields := make(map[string]bool)
+	e.ast.identifiers(fields)
+	synthetic := make(map[string]float64, len(fields))
+	for f := range fields {
+		synthetic[f] = 1
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	_, evalErr := e.ast.eval(&evalEnv{record: synthetic, onMissing: e.onMissing})
+	return evalErr
+}
+
+// Score evaluates the expression against record. It returns an error if
+// on_missing is "error" and record is missing a field the expression
+// references; dryRun only validates the expression against a synthetic
+// record supplying every field, so this is a normal, expected outcome of
+// scoring real input rather than a sign of a bad config.
+func (e *expr) Score(record map[string]float64) (float64, error) {
+	return e.ast.eval(&evalEnv{record: record, onMissing: e.onMissing})
+}
+
+// evalEnv carries the per-row state needed to evaluate an expression.
+type evalEnv struct {
+	record    map[string]float64
+	onMissing OnMissing
+}
+
+func (e *evalEnv) lookup(name string) (float64, error) {
+	if v, ok := e.record[name]; ok {
+		return v, nil
+	}
+	switch e.onMissing {
+	case OnMissingError:
+		return 0, &EvalError{Msg: fmt.Sprintf("missing field %q", name)}
+	case OnMissingNaN:
+		return math.NaN(), nil
+	default: // OnMissingZero
+		return 0, nil
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"math"
+	"testing"
+)
+
+func newExpr(t *testing.T, expression string, onMissing OnMissing) *expr {
+	t.Helper()
+	c := Config{Expression: expression, OnMissing: onMissing}
+	a, err := New(func(v interface{}) error {
+		*(v.(*Config)) = c
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("New(%q) failed: %v", expression, err)
+	}
+	return a.(*expr)
+}
+
+func TestScoreArithmetic(t *testing.T) {
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"2 ^ 3 ^ 2", 512}, // right-associative: 2^(3^2)
+		{"-2 + 3", 1},
+		{"clamp(5, 0, 1)", 1},
+		{"min(3, 1, 2)", 1},
+		{"max(3, 1, 2)", 3},
+		{"linear(5, 0, 10)", 0.5},
+	}
+	for _, tc := range tests {
+		e := newExpr(t, tc.expr, "")
+		got, err := e.Score(nil)
+		if err != nil {
+			t.Fatalf("Score(%q) failed: %v", tc.expr, err)
+		}
+		if got != tc.want {
+			t.Errorf("Score(%q) = %v, want %v", tc.expr, got, tc.want)
+		}
+	}
+}
+
+func TestScoreUsesRecordFields(t *testing.T) {
+	e := newExpr(t, "0.4*log(1+contributors) + 0.6*clamp(recent_commits/100,0,1)", "")
+	got, err := e.Score(map[string]float64{"contributors": math.E - 1, "recent_commits": 50})
+	if err != nil {
+		t.Fatalf("Score() failed: %v", err)
+	}
+	want := 0.4*1 + 0.6*0.5
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("Score() = %v, want %v", got, want)
+	}
+}
+
+func TestOnMissingZero(t *testing.T) {
+	e := newExpr(t, "missing_field + 1", OnMissingZero)
+	got, err := e.Score(map[string]float64{})
+	if err != nil {
+		t.Fatalf("Score() failed: %v", err)
+	}
+	if got != 1 {
+		t.Errorf("Score() = %v, want 1", got)
+	}
+}
+
+func TestOnMissingNaN(t *testing.T) {
+	e := newExpr(t, "missing_field + 1", OnMissingNaN)
+	got, err := e.Score(map[string]float64{})
+	if err != nil {
+		t.Fatalf("Score() failed: %v", err)
+	}
+	if !math.IsNaN(got) {
+		t.Errorf("Score() = %v, want NaN", got)
+	}
+}
+
+func TestOnMissingErrorReturnsError(t *testing.T) {
+	e := newExpr(t, "missing_field + 1", OnMissingError)
+	if _, err := e.Score(map[string]float64{}); err == nil {
+		t.Errorf("Score() did not return an error")
+	}
+}
+
+func TestNewRejectsBadExpression(t *testing.T) {
+	tests := []string{
+		"1 +",
+		"unknown_fn(1)",
+		"clamp(1, 2)",
+		"(1 + 2",
+	}
+	for _, expression := range tests {
+		c := Config{Expression: expression}
+		_, err := New(func(v interface{}) error {
+			*(v.(*Config)) = c
+			return nil
+		})
+		if err == nil {
+			t.Errorf("New(%q) succeeded, want error", expression)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expression
+
+import (
+	"fmt"
+	"math"
+)
+
+// function implements a named function callable from an expression.
+type function func(args []float64) (float64, error)
+
+// functions is the fixed table of functions callable from an expression.
+var functions = map[string]function{
+	"log":    unaryFn(math.Log),
+	"exp":    unaryFn(math.Exp),
+	"min":    variadicFn(math.Min),
+	"max":    variadicFn(math.Max),
+	"clamp":  clampFn,
+	"zipf":   unaryFn(math.Log1p),
+	"linear": linearFn,
+}
+
+func unaryFn(f func(float64) float64) function {
+	return func(args []float64) (float64, error) {
+		if len(args) != 1 {
+			return 0, fmt.Errorf("expected 1 argument, got %d", len(args))
+		}
+		return f(args[0]), nil
+	}
+}
+
+func variadicFn(f func(a, b float64) float64) function {
+	return func(args []float64) (float64, error) {
+		if len(args) == 0 {
+			return 0, fmt.Errorf("expected at least 1 argument, got 0")
+		}
+		result := args[0]
+		for _, a := range args[1:] {
+			result = f(result, a)
+		}
+		return result, nil
+	}
+}
+
+// clampFn implements clamp(x, lower, upper), constraining x to [lower, upper].
+func clampFn(args []float64) (float64, error) {
+	if len(args) != 3 {
+		return 0, fmt.Errorf("expected 3 arguments, got %d", len(args))
+	}
+	x, lower, upper := args[0], args[1], args[2]
+	switch {
+	case x < lower:
+		return lower, nil
+	case x > upper:
+		return upper, nil
+	default:
+		return x, nil
+	}
+}
+
+// linearFn implements linear(x, lower, upper), linearly rescaling x from
+// [lower, upper] to [0, 1], clamping values outside of the range.
+func linearFn(args []float64) (float64, error) {
+	if len(args) != 3 {
+		return 0, fmt.Errorf("expected 3 arguments, got %d", len(args))
+	}
+	x, lower, upper := args[0], args[1], args[2]
+	if upper == lower {
+		return 0, fmt.Errorf("linear: upper and lower bound must differ")
+	}
+	v, err := clampFn([]float64{x, lower, upper})
+	if err != nil {
+		return 0, err
+	}
+	return (v - lower) / (upper - lower), nil
+}
+
+func pow(x, y float64) float64 {
+	return math.Pow(x, y)
+}
@@ -0,0 +1,62 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wam implements the "wam" (weighted arithmetic mean) criticality
+// scoring algorithm: each configured field contributes weight*value to the
+// score, normalized by the sum of the weights.
+package wam
+
+import "github.com/ossf/criticality_score/cmd/scorer/algorithm"
+
+func init() {
+	algorithm.Register("wam", New)
+}
+
+// FieldConfig is the per-field configuration accepted under `fields:` when
+// `algorithm: wam` is used.
+type FieldConfig struct {
+	Weight float64 `yaml:"weight"`
+}
+
+// Config is a wam scorer config file, keyed by the `fields:` section.
+type Config struct {
+	Fields map[string]FieldConfig `yaml:"fields"`
+}
+
+// wam scores a record as the weighted arithmetic mean of its configured
+// fields.
+type wam struct {
+	fields map[string]FieldConfig
+}
+
+// New constructs a wam Algorithm, decoding its field weights via unmarshal.
+func New(unmarshal func(interface{}) error) (algorithm.Algorithm, error) {
+	var c Config
+	if err := unmarshal(&c); err != nil {
+		return nil, err
+	}
+	return &wam{fields: c.Fields}, nil
+}
+
+func (w *wam) Score(record map[string]float64) (float64, error) {
+	var sum, totalWeight float64
+	for name, fc := range w.fields {
+		sum += fc.Weight * record[name]
+		totalWeight += fc.Weight
+	}
+	if totalWeight == 0 {
+		return 0, nil
+	}
+	return sum / totalWeight, nil
+}
@@ -0,0 +1,106 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ossf/criticality_score/cmd/scorer/algorithm"
+)
+
+// stringListFlag accumulates every occurrence of a repeatable `-flag value`
+// into a slice, in the order they appear on the command line.
+type stringListFlag []string
+
+func (s *stringListFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringListFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// scoreColumn is a single scoring pass applied to every row: it runs
+// algorithm, and writes the resulting score into the output row at
+// index, which may coincide with an existing input column (an
+// -allow-score-override) or a newly appended one.
+type scoreColumn struct {
+	name      string
+	algorithm algorithm.Algorithm
+	index     int
+}
+
+// planScoreColumns extends header with names that aren't already present,
+// and resolves the output index for each name. If a name is already
+// present in header, it resolves to that column's index only if
+// allowOverride is set; otherwise it's an error, matching the historic
+// single-config behavior.
+func planScoreColumns(header []string, names []string, allowOverride bool) (outHeader []string, indices []int, err error) {
+	outHeader = append([]string(nil), header...)
+	positions := make(map[string]int, len(outHeader))
+	for i, h := range outHeader {
+		positions[h] = i
+	}
+	indices = make([]int, len(names))
+	for i, name := range names {
+		if idx, ok := positions[name]; ok {
+			if !allowOverride {
+				return nil, nil, fmt.Errorf("header already contains field %s", name)
+			}
+			indices[i] = idx
+			continue
+		}
+		indices[i] = len(outHeader)
+		outHeader = append(outHeader, name)
+		positions[name] = indices[i]
+	}
+	return outHeader, indices, nil
+}
+
+// sortColumnIndex returns the output index that the priority queue should
+// sort by: the column named sortBy if it's set, otherwise the last
+// scoring column, matching the historic behavior of sorting by the only
+// (and therefore last) score column.
+func sortColumnIndex(cols []scoreColumn, sortBy string) (int, error) {
+	if sortBy == "" {
+		return cols[len(cols)-1].index, nil
+	}
+	for _, c := range cols {
+		if c.name == sortBy {
+			return c.index, nil
+		}
+	}
+	return 0, fmt.Errorf("sort-by column %q is not one of the scored columns", sortBy)
+}
+
+// makeBaseRow builds the portion of the output row drawn from the input:
+// fields that parsed as numbers are kept as float64 (so JSON output
+// preserves them as numbers rather than strings), everything else is
+// kept as the raw string read from the input row. The row is sized to
+// outLen, leaving appended score columns zero-valued for the caller to
+// fill in.
+func makeBaseRow(inHeader, row []string, record map[string]float64, outLen int) []interface{} {
+	out := make([]interface{}, outLen)
+	for i, h := range inHeader {
+		if v, ok := record[h]; ok {
+			out[i] = v
+		} else {
+			out[i] = row[i]
+		}
+	}
+	return out
+}
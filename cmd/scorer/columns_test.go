@@ -0,0 +1,95 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPlanScoreColumnsAppendsNewNames(t *testing.T) {
+	header := []string{"a", "b"}
+	outHeader, indices, err := planScoreColumns(header, []string{"score1", "score2"}, false)
+	if err != nil {
+		t.Fatalf("planScoreColumns: %v", err)
+	}
+	if want := []string{"a", "b", "score1", "score2"}; !reflect.DeepEqual(outHeader, want) {
+		t.Errorf("outHeader = %v, want %v", outHeader, want)
+	}
+	if want := []int{2, 3}; !reflect.DeepEqual(indices, want) {
+		t.Errorf("indices = %v, want %v", indices, want)
+	}
+}
+
+func TestPlanScoreColumnsRejectsExistingNameByDefault(t *testing.T) {
+	header := []string{"a", "b"}
+	if _, _, err := planScoreColumns(header, []string{"b"}, false); err == nil {
+		t.Fatal("expected an error reusing an existing field name, got nil")
+	}
+}
+
+func TestPlanScoreColumnsAllowsOverride(t *testing.T) {
+	header := []string{"a", "b"}
+	outHeader, indices, err := planScoreColumns(header, []string{"b"}, true)
+	if err != nil {
+		t.Fatalf("planScoreColumns: %v", err)
+	}
+	if want := []string{"a", "b"}; !reflect.DeepEqual(outHeader, want) {
+		t.Errorf("outHeader = %v, want %v", outHeader, want)
+	}
+	if want := []int{1}; !reflect.DeepEqual(indices, want) {
+		t.Errorf("indices = %v, want %v", indices, want)
+	}
+}
+
+func TestSortColumnIndexDefaultsToLastColumn(t *testing.T) {
+	cols := []scoreColumn{{name: "score1", index: 2}, {name: "score2", index: 3}}
+	idx, err := sortColumnIndex(cols, "")
+	if err != nil {
+		t.Fatalf("sortColumnIndex: %v", err)
+	}
+	if idx != 3 {
+		t.Errorf("idx = %d, want 3", idx)
+	}
+}
+
+func TestSortColumnIndexHonorsSortBy(t *testing.T) {
+	cols := []scoreColumn{{name: "score1", index: 2}, {name: "score2", index: 3}}
+	idx, err := sortColumnIndex(cols, "score1")
+	if err != nil {
+		t.Fatalf("sortColumnIndex: %v", err)
+	}
+	if idx != 2 {
+		t.Errorf("idx = %d, want 2", idx)
+	}
+}
+
+func TestSortColumnIndexRejectsUnknownName(t *testing.T) {
+	cols := []scoreColumn{{name: "score1", index: 2}}
+	if _, err := sortColumnIndex(cols, "nope"); err == nil {
+		t.Fatal("expected an error for an unknown sort-by column, got nil")
+	}
+}
+
+func TestMakeBaseRowKeepsNumericFieldsAsFloat64(t *testing.T) {
+	inHeader := []string{"a", "b"}
+	row := []string{"1.5", "text"}
+	record := map[string]float64{"a": 1.5}
+	out := makeBaseRow(inHeader, row, record, 3)
+	want := []interface{}{1.5, "text", nil}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("out = %v, want %v", out, want)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ossf/criticality_score/cmd/scorer/algorithm"
+)
+
+// Config is the top-level structure of a scorer YAML config file. Only
+// the `algorithm:` field is read up-front; the rest of the document is
+// handed to the chosen algorithm's Factory to decode however it needs,
+// since each algorithm defines its own config shape (e.g. "wam" expects a
+// `fields:` section, "expression" expects an `expression:` string).
+type Config struct {
+	// Name is the registered algorithm to use, e.g. "wam" or "expression".
+	Name string `yaml:"algorithm"`
+	raw  yaml.Node
+}
+
+// LoadConfig parses a scorer config file read from r.
+func LoadConfig(r io.Reader) (*Config, error) {
+	var raw yaml.Node
+	if err := yaml.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	var c struct {
+		Name string `yaml:"algorithm"`
+	}
+	if err := raw.Decode(&c); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	return &Config{Name: c.Name, raw: raw}, nil
+}
+
+// Algorithm constructs the algorithm.Algorithm named by c.Name.
+func (c *Config) Algorithm() (algorithm.Algorithm, error) {
+	factory, ok := algorithm.Get(c.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown algorithm: %s", c.Name)
+	}
+	return factory(c.raw.Decode)
+}
@@ -35,34 +35,49 @@ package main
 import (
 	"context"
 	"encoding/csv"
-	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"os"
 	"path"
 	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 
+	"github.com/ossf/criticality_score/cmd/scorer/algorithm"
+	_ "github.com/ossf/criticality_score/cmd/scorer/algorithm/expression"
 	_ "github.com/ossf/criticality_score/cmd/scorer/algorithm/wam"
 	log "github.com/ossf/criticality_score/internal/log"
+	"github.com/ossf/criticality_score/internal/metrics"
 	"github.com/ossf/criticality_score/internal/outfile"
 )
 
 const defaultLogLevel = zapcore.InfoLevel
 
 var (
-	configFlag     = flag.String("config", "", "the filename of the config (required)")
-	columnNameFlag = flag.String("column", "", "the name of the output column")
-	logLevel       = defaultLogLevel
-	logEnv         log.Env
+	configFlags        stringListFlag
+	columnFlags        stringListFlag
+	allowScoreOverride = flag.Bool("allow-score-override", false, "allow a -column to reuse an existing field name, overwriting its values, instead of erroring")
+	sortByFlag         = flag.String("sort-by", "", "the `column` to sort output by (default: the last -config's column)")
+	formatFlag         = flag.String("format", "csv", "the output `format`: csv, json or jsonl")
+	topFlag            = flag.Int("top", 0, "only output the top `N` highest-scoring rows (0 means no limit); cannot be combined with -streaming")
+	limitFlag          = flag.Int("limit", 0, "alias for -top")
+	streamingFlag      = flag.Bool("streaming", false, "write each scored row immediately, in input order, instead of sorting by score")
+	workersFlag        = flag.Int("workers", runtime.NumCPU(), "number of `N` parallel scoring workers")
+	metricsAddr        = flag.String("metrics-addr", "", "if set, serve Prometheus metrics on this `address` (e.g. :9090)")
+	progressEvery      = flag.Int64("progress-every", 100_000, "log a progress update every `N` rows (0 disables this trigger)")
+	progressPeriod     = flag.Duration("progress-interval", 30*time.Second, "log a progress update at least this often (0 disables this trigger)")
+	logLevel           = defaultLogLevel
+	logEnv             log.Env
 )
 
 func init() {
+	flag.Var(&configFlags, "config", "the filename of a config (required, repeatable to apply multiple algorithms in one pass)")
+	flag.Var(&columnFlags, "column", "the name of the output column for the -config given at the same position (repeatable)")
 	flag.Var(&logLevel, "log", "set the `level` of logging.")
 	flag.TextVar(&logEnv, "log-env", log.DefaultEnv, "set logging `env`.")
 	outfile.DefineFlags(flag.CommandLine, "force", "append", "OUT_FILE") // TODO: add the ability to disable "append"
@@ -78,13 +93,15 @@ func init() {
 	}
 }
 
-func generateColumnName() string {
-	if *columnNameFlag != "" {
-		// If we have the column name, just use it as the name
-		return *columnNameFlag
+// columnNameFor returns the output column name for the i'th -config: the
+// i'th -column if one was given, otherwise a name derived from the
+// config's filename.
+func columnNameFor(i int, configFilename string) string {
+	if i < len(columnFlags) && columnFlags[i] != "" {
+		return columnFlags[i]
 	}
 	// Get the name of the config file used, without the path
-	f := path.Base(*configFlag)
+	f := path.Base(configFilename)
 	ext := path.Ext(f)
 	// Strip the extension and convert to lowercase
 	f = strings.ToLower(strings.TrimSuffix(f, ext))
@@ -94,22 +111,18 @@ func generateColumnName() string {
 	return f + "_score"
 }
 
-func makeOutHeader(header []string, resultColumn string) ([]string, error) {
-	for _, h := range header {
-		if h == resultColumn {
-			return nil, fmt.Errorf("header already contains field %s", resultColumn)
-		}
-	}
-	return append(header, resultColumn), nil
-}
-
-func makeRecord(header, row []string) map[string]float64 {
+// makeRecord parses row into a record keyed by header. Fields that don't
+// parse as a float are silently dropped from the record, after bumping m's
+// per-field parse-error counter, if m is non-nil.
+func makeRecord(header, row []string, m *metrics.Metrics) map[string]float64 {
 	record := make(map[string]float64)
 	for i, k := range header {
 		raw := row[i]
 		v, err := strconv.ParseFloat(raw, 64)
 		if err != nil {
-			// Failed to parse raw into a float, ignore the field
+			if m != nil {
+				m.ParseErrors.WithLabelValues(k).Inc()
+			}
 			continue
 		}
 		record[k] = v
@@ -164,90 +177,150 @@ func main() {
 		os.Exit(2)
 	}
 	defer f.Close()
-	w := csv.NewWriter(f)
+	w, err := newRecordWriter(*formatFlag, f)
+	if err != nil {
+		logger.With(
+			zap.Error(err),
+			zap.String("format", *formatFlag),
+		).Error("Failed to create output writer")
+		os.Exit(2)
+	}
 	defer w.Flush()
 
-	// Prepare the algorithm from the config file
-	if *configFlag == "" {
+	// Prepare the algorithms from the config files
+	if len(configFlags) == 0 {
 		logger.Error("Must have a config file set")
 		os.Exit(2)
 	}
 
-	cf, err := os.Open(*configFlag)
+	names := make([]string, len(configFlags))
+	algorithms := make([]algorithm.Algorithm, len(configFlags))
+	for i, configFilename := range configFlags {
+		cf, err := os.Open(configFilename)
+		if err != nil {
+			logger.With(
+				zap.Error(err),
+				zap.String("filename", configFilename),
+			).Error("Failed to open config file")
+			os.Exit(2)
+		}
+		c, err := LoadConfig(cf)
+		cf.Close()
+		if err != nil {
+			logger.With(
+				zap.Error(err),
+				zap.String("filename", configFilename),
+			).Error("Failed to parse config file")
+			os.Exit(2)
+		}
+		a, err := c.Algorithm()
+		if err != nil {
+			logger.With(
+				zap.Error(err),
+				zap.String("algorithm", c.Name),
+			).Error("Failed to get the algorithm")
+			os.Exit(2)
+		}
+		names[i] = columnNameFor(i, configFilename)
+		algorithms[i] = a
+	}
+
+	inHeader, err := r.Read()
 	if err != nil {
 		logger.With(
 			zap.Error(err),
-			zap.String("filename", *configFlag),
-		).Error("Failed to open config file")
+		).Error("Failed to read CSV header row")
 		os.Exit(2)
 	}
-	c, err := LoadConfig(cf)
+
+	// Generate and output the CSV header row
+	outHeader, indices, err := planScoreColumns(inHeader, names, *allowScoreOverride)
 	if err != nil {
 		logger.With(
 			zap.Error(err),
-			zap.String("filename", *configFlag),
-		).Error("Failed to parse config file")
+		).Error("Failed to generate output header row")
 		os.Exit(2)
 	}
-	a, err := c.Algorithm()
-	if err != nil {
+	if err := w.WriteHeader(outHeader); err != nil {
 		logger.With(
 			zap.Error(err),
-			zap.String("algorithm", c.Name),
-		).Error("Failed to get the algorithm")
+		).Error("Failed to write output header row")
 		os.Exit(2)
 	}
 
-	inHeader, err := r.Read()
+	cols := make([]scoreColumn, len(algorithms))
+	for i := range algorithms {
+		cols[i] = scoreColumn{name: names[i], algorithm: algorithms[i], index: indices[i]}
+	}
+	sortIdx, err := sortColumnIndex(cols, *sortByFlag)
 	if err != nil {
 		logger.With(
 			zap.Error(err),
-		).Error("Failed to read CSV header row")
+		).Error("Failed to resolve -sort-by column")
 		os.Exit(2)
 	}
 
-	// Generate and output the CSV header row
-	outHeader, err := makeOutHeader(inHeader, generateColumnName())
-	if err != nil {
-		logger.With(
-			zap.Error(err),
-		).Error("Failed to generate output header row")
-		os.Exit(2)
+	limit := *topFlag
+	if limit == 0 {
+		limit = *limitFlag
 	}
-	if err := w.Write(outHeader); err != nil {
-		logger.With(
-			zap.Error(err),
-		).Error("Failed to write CSV header row")
+	if *streamingFlag && limit > 0 {
+		logger.Error("-top/-limit cannot be combined with -streaming: streaming writes every row as it's scored, before the top N are known")
 		os.Exit(2)
 	}
-
-	var pq PriorityQueue
-	for {
-		row, err := r.Read()
-		if errors.Is(err, io.EOF) {
-			break
+	var rq RowQueue
+	if !*streamingFlag {
+		if limit > 0 {
+			rq = NewBoundedPriorityQueue(limit)
+		} else {
+			rq = &PriorityQueue{}
 		}
-		if err != nil {
-			logger.With(
-				zap.Error(err),
-			).Error("Failed to read CSV row")
-			os.Exit(2)
+	}
+
+	m := metrics.New("scorer")
+	if *metricsAddr != "" {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			if err := m.Serve(ctx, *metricsAddr, logger); err != nil {
+				logger.With(
+					zap.Error(err),
+					zap.String("addr", *metricsAddr),
+				).Error("Metrics server failed")
+			}
+		}()
+	}
+	progress := metrics.NewProgress(logger, *progressEvery, *progressPeriod, 0)
+
+	handle := func(sr scoredRow) error {
+		if *streamingFlag {
+			return w.WriteRecord(sr.row)
 		}
-		record := makeRecord(inHeader, row)
-		score := a.Score(record)
-		row = append(row, fmt.Sprintf("%.5f", score))
-		pq.PushRow(row, score)
+		rq.PushRow(sr.row, sr.score, sr.seq)
+		return nil
+	}
+	if err := scoreCSV(r, inHeader, cols, len(outHeader), sortIdx, scoreOptions{
+		workers:  *workersFlag,
+		metrics:  m,
+		progress: progress,
+	}, handle); err != nil {
+		logger.With(
+			zap.Error(err),
+		).Error("Failed to score CSV rows")
+		os.Exit(2)
 	}
 
-	// Iterate over the pq and send the results to the output csv.
-	t := pq.Len()
-	for i := 0; i < t; i++ {
-		if err := w.Write(pq.PopRow()); err != nil {
-			logger.With(
-				zap.Error(err),
-			).Error("Failed to write CSV header row")
-			os.Exit(2)
+	// Iterate over the queue and send the results to the output, sorted
+	// by score.
+	if !*streamingFlag {
+		t := rq.Len()
+		for i := 0; i < t; i++ {
+			if err := w.WriteRecord(rq.PopRow()); err != nil {
+				logger.With(
+					zap.Error(err),
+				).Error("Failed to write output row")
+				os.Exit(2)
+			}
 		}
 	}
-	// -allow-score-override -- if the output field exists overwrite the existing data
 }
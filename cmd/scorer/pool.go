@@ -0,0 +1,187 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/ossf/criticality_score/internal/metrics"
+)
+
+// scoredRow is the result of scoring a single input row.
+type scoredRow struct {
+	seq   int
+	row   []interface{}
+	score float64
+}
+
+// scoreOptions configures scoreCSV.
+type scoreOptions struct {
+	// workers is the number of parallel scoring goroutines to run. Values
+	// below 1 are treated as 1.
+	workers int
+	// metrics, if non-nil, is updated with rows read/scored, per-field
+	// parse errors, and scoring latency/score distributions.
+	metrics *metrics.Metrics
+	// progress, if non-nil, is notified after every row is scored.
+	progress *metrics.Progress
+}
+
+// scoreCSV reads rows from r (the header row must already have been
+// consumed), running every row through each of cols using a pool of
+// opts.workers goroutines, and calls handle once per row in ascending
+// input order -- regardless of which worker happens to finish scoring it
+// first. Each column's score is written into the output row at its
+// configured index; sortIdx selects which of those scores is reported as
+// scoredRow.score, for use as the sort key by the caller's RowQueue. It
+// reads until r is exhausted, returning the first error encountered
+// reading the CSV, scoring a row, or from handle.
+//
+// A single goroutine reads rows and fans them out to the worker pool;
+// scored rows are sent back over a single results channel, so only one
+// goroutine (this one) ever touches handle, avoiding the need for a
+// mutex around it.
+func scoreCSV(r *csv.Reader, inHeader []string, cols []scoreColumn, outLen, sortIdx int, opts scoreOptions, handle func(scoredRow) error) error {
+	workers := opts.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	type job struct {
+		seq int
+		row []string
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := make(chan job)
+	results := make(chan scoredRow)
+	readErr := make(chan error, 1)
+	scoreErr := make(chan error, 1)
+
+	go func() {
+		defer close(jobs)
+		seq := 0
+		for {
+			row, err := r.Read()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if err != nil {
+				readErr <- err
+				return
+			}
+			if opts.metrics != nil {
+				opts.metrics.RowsRead.Inc()
+			}
+			select {
+			case jobs <- job{seq: seq, row: row}:
+			case <-ctx.Done():
+				return
+			}
+			seq++
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				select {
+				case <-ctx.Done():
+					// A sibling worker already hit a scoring error;
+					// drain the remaining jobs without scoring them.
+					continue
+				default:
+				}
+				start := time.Now()
+				record := makeRecord(inHeader, j.row, opts.metrics)
+				outRow := makeBaseRow(inHeader, j.row, record, outLen)
+				failed := false
+				for _, c := range cols {
+					s, err := c.algorithm.Score(record)
+					if err != nil {
+						select {
+						case scoreErr <- err:
+						default:
+						}
+						cancel()
+						failed = true
+						break
+					}
+					outRow[c.index] = s
+					if opts.metrics != nil {
+						opts.metrics.ScoreValue.Observe(s)
+					}
+				}
+				if failed {
+					continue
+				}
+				if opts.metrics != nil {
+					opts.metrics.ScoreLatency.Observe(time.Since(start).Seconds())
+					opts.metrics.RowsScored.Inc()
+				}
+				if opts.progress != nil {
+					opts.progress.Inc()
+				}
+				results <- scoredRow{seq: j.seq, row: outRow, score: outRow[sortIdx].(float64)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	// Reorder results as they arrive: buffer whatever's out of order and
+	// hand rows to handle as soon as the next expected seq is available.
+	pending := make(map[int]scoredRow)
+	next := 0
+	for res := range results {
+		pending[res.seq] = res
+		for {
+			row, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			next++
+			if err := handle(row); err != nil {
+				cancel()
+				for range results {
+					// Drain so the producer and workers don't block.
+				}
+				return err
+			}
+		}
+	}
+
+	select {
+	case err := <-scoreErr:
+		return err
+	case err := <-readErr:
+		return err
+	default:
+		return nil
+	}
+}
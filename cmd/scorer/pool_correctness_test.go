@@ -0,0 +1,106 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/ossf/criticality_score/internal/metrics"
+)
+
+type identityAlgorithm struct{}
+
+func (identityAlgorithm) Score(record map[string]float64) (float64, error) {
+	return record["a"], nil
+}
+
+// failingAlgorithm always returns an error, simulating e.g. an expression
+// algorithm with on_missing: error hitting a row missing a field it needs.
+type failingAlgorithm struct{}
+
+var errFailingAlgorithm = errors.New("failingAlgorithm: intentional failure")
+
+func (failingAlgorithm) Score(record map[string]float64) (float64, error) {
+	return 0, errFailingAlgorithm
+}
+
+func TestScoreCSVPreservesInputOrder(t *testing.T) {
+	const rows = 2000
+	r := syntheticCSV(rows)
+	cols := []scoreColumn{{name: "score", algorithm: identityAlgorithm{}, index: 1}}
+	var seen []int
+	err := scoreCSV(r, []string{"a"}, cols, 2, 1, scoreOptions{workers: 8}, func(sr scoredRow) error {
+		seen = append(seen, sr.seq)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scoreCSV: %v", err)
+	}
+	if len(seen) != rows {
+		t.Fatalf("got %d rows, want %d", len(seen), rows)
+	}
+	for i, seq := range seen {
+		if seq != i {
+			t.Fatalf("row %d arrived out of order: seq=%d", i, seq)
+		}
+	}
+}
+
+func TestScoreCSVUpdatesMetrics(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("1,notanumber\n2,3\n")
+	r := csv.NewReader(&buf)
+	cols := []scoreColumn{{name: "score", algorithm: identityAlgorithm{}, index: 2}}
+	m := metrics.New("test_pool")
+
+	err := scoreCSV(r, []string{"a", "b"}, cols, 3, 2, scoreOptions{workers: 4, metrics: m}, func(scoredRow) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("scoreCSV: %v", err)
+	}
+
+	if got := testutil.ToFloat64(m.RowsRead); got != 2 {
+		t.Errorf("RowsRead = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.RowsScored); got != 2 {
+		t.Errorf("RowsScored = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.ParseErrors.WithLabelValues("b")); got != 1 {
+		t.Errorf("ParseErrors{b} = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ScoreLatency); got != 1 {
+		t.Errorf("ScoreLatency CollectAndCount = %d, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ScoreValue); got != 1 {
+		t.Errorf("ScoreValue CollectAndCount = %d, want 1", got)
+	}
+}
+
+func TestScoreCSVReturnsScoringErrorInsteadOfPanicking(t *testing.T) {
+	r := syntheticCSV(2000)
+	cols := []scoreColumn{{name: "score", algorithm: failingAlgorithm{}, index: 1}}
+	err := scoreCSV(r, []string{"a"}, cols, 2, 1, scoreOptions{workers: 8}, func(scoredRow) error {
+		return nil
+	})
+	if !errors.Is(err, errFailingAlgorithm) {
+		t.Fatalf("scoreCSV = %v, want %v", err, errFailingAlgorithm)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"testing"
+)
+
+// slowAlgorithm simulates a non-trivial scoring algorithm by doing some
+// busywork, so that benchmark results reflect scheduling overhead rather
+// than being dominated by I/O.
+type slowAlgorithm struct{}
+
+func (slowAlgorithm) Score(record map[string]float64) (float64, error) {
+	sum := 0.0
+	for i := 0; i < 200; i++ {
+		sum += record["a"] * float64(i) / (float64(i) + 1)
+	}
+	return sum, nil
+}
+
+func syntheticCSV(rows int) *csv.Reader {
+	var buf bytes.Buffer
+	for i := 0; i < rows; i++ {
+		fmt.Fprintf(&buf, "%d\n", i)
+	}
+	return csv.NewReader(&buf)
+}
+
+func benchmarkScoreCSV(b *testing.B, workers int) {
+	header := []string{"a"}
+	for i := 0; i < b.N; i++ {
+		r := syntheticCSV(1_000_000)
+		cols := []scoreColumn{{name: "score", algorithm: slowAlgorithm{}, index: 1}}
+		err := scoreCSV(r, header, cols, 2, 1, scoreOptions{workers: workers}, func(scoredRow) error {
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScoreCSV_1Worker(b *testing.B) {
+	benchmarkScoreCSV(b, 1)
+}
+
+func BenchmarkScoreCSV_4Workers(b *testing.B) {
+	benchmarkScoreCSV(b, 4)
+}
+
+func BenchmarkScoreCSV_16Workers(b *testing.B) {
+	benchmarkScoreCSV(b, 16)
+}
@@ -0,0 +1,180 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// RowQueue buffers scored rows, then drains them highest-score first.
+// PriorityQueue and BoundedPriorityQueue both implement it.
+type RowQueue interface {
+	// PushRow adds row to the queue with the given score. seq is the
+	// row's position in the input, used to break ties between equal
+	// scores so that output order is deterministic even when rows are
+	// scored out of order by a pool of parallel workers.
+	PushRow(row []interface{}, score float64, seq int)
+	// Len returns the number of rows currently held by the queue.
+	Len() int
+	// PopRow removes and returns the highest-scoring row still in the
+	// queue.
+	PopRow() []interface{}
+}
+
+// queueItem is a single scored row held by a PriorityQueue.
+type queueItem struct {
+	row   []interface{}
+	score float64
+	seq   int
+}
+
+// PriorityQueue orders scored rows from highest to lowest score, so that
+// draining it with PopRow yields rows in descending order of score.
+type PriorityQueue struct {
+	items []*queueItem
+}
+
+// PushRow adds row to the queue with the given score and input position.
+func (pq *PriorityQueue) PushRow(row []interface{}, score float64, seq int) {
+	heap.Push(pq, &queueItem{row: row, score: score, seq: seq})
+}
+
+// PopRow removes and returns the highest-scoring row still in the queue.
+func (pq *PriorityQueue) PopRow() []interface{} {
+	return heap.Pop(pq).(*queueItem).row
+}
+
+// Len returns the number of rows currently held by the queue.
+func (pq *PriorityQueue) Len() int {
+	return len(pq.items)
+}
+
+// Less implements heap.Interface: the highest score sorts first, so that
+// PopRow drains in descending score order; rows with equal scores sort by
+// input position, so draining is deterministic regardless of the order
+// rows were scored and pushed in.
+func (pq *PriorityQueue) Less(i, j int) bool {
+	if pq.items[i].score != pq.items[j].score {
+		return pq.items[i].score > pq.items[j].score
+	}
+	return pq.items[i].seq < pq.items[j].seq
+}
+
+// Swap implements heap.Interface.
+func (pq *PriorityQueue) Swap(i, j int) {
+	pq.items[i], pq.items[j] = pq.items[j], pq.items[i]
+}
+
+// Push implements heap.Interface. Use PushRow instead of calling this
+// directly.
+func (pq *PriorityQueue) Push(x interface{}) {
+	pq.items = append(pq.items, x.(*queueItem))
+}
+
+// Pop implements heap.Interface. Use PopRow instead of calling this
+// directly.
+func (pq *PriorityQueue) Pop() interface{} {
+	old := pq.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	pq.items = old[:n-1]
+	return item
+}
+
+// minItems is a min-heap of queueItems, ordered by ascending score (and,
+// among equal scores, by descending input position), used internally by
+// BoundedPriorityQueue to track the current lowest-ranked row of the
+// retained top-N.
+type minItems []*queueItem
+
+func (m minItems) Len() int { return len(m) }
+func (m minItems) Less(i, j int) bool {
+	if m[i].score != m[j].score {
+		return m[i].score < m[j].score
+	}
+	return m[i].seq > m[j].seq
+}
+func (m minItems) Swap(i, j int)       { m[i], m[j] = m[j], m[i] }
+func (m *minItems) Push(x interface{}) { *m = append(*m, x.(*queueItem)) }
+
+func (m *minItems) Pop() interface{} {
+	old := *m
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*m = old[:n-1]
+	return item
+}
+
+// BoundedPriorityQueue retains only the N highest-scoring rows pushed to
+// it, using an O(log N) min-heap replace-if-greater update, so memory
+// stays bounded to N rows regardless of how many are pushed. This makes
+// it suitable for "top K" scoring runs over inputs with millions of rows.
+type BoundedPriorityQueue struct {
+	limit  int
+	items  minItems
+	sorted []*queueItem // populated lazily by the first PopRow
+}
+
+// NewBoundedPriorityQueue returns a BoundedPriorityQueue that retains at
+// most the limit highest-scoring rows pushed to it. A limit of 0 retains
+// nothing.
+func NewBoundedPriorityQueue(limit int) *BoundedPriorityQueue {
+	return &BoundedPriorityQueue{limit: limit}
+}
+
+// PushRow adds row to the queue with the given score and input position,
+// evicting the current lowest-ranked retained row if the queue is already
+// at its limit and row ranks higher: it scores higher, or scores the same
+// and comes earlier in the input.
+func (pq *BoundedPriorityQueue) PushRow(row []interface{}, score float64, seq int) {
+	if pq.limit <= 0 {
+		return
+	}
+	if len(pq.items) < pq.limit {
+		heap.Push(&pq.items, &queueItem{row: row, score: score, seq: seq})
+		return
+	}
+	if min := pq.items[0]; score > min.score || (score == min.score && seq < min.seq) {
+		pq.items[0] = &queueItem{row: row, score: score, seq: seq}
+		heap.Fix(&pq.items, 0)
+	}
+}
+
+// Len returns the number of rows currently retained by the queue.
+func (pq *BoundedPriorityQueue) Len() int {
+	return len(pq.items) + len(pq.sorted)
+}
+
+// PopRow removes and returns the highest-scoring row still retained by
+// the queue. The first call sorts the retained rows, so that subsequent
+// calls are O(1).
+func (pq *BoundedPriorityQueue) PopRow() []interface{} {
+	if pq.sorted == nil {
+		pq.sorted = []*queueItem(pq.items)
+		sort.Slice(pq.sorted, func(i, j int) bool {
+			if pq.sorted[i].score != pq.sorted[j].score {
+				return pq.sorted[i].score > pq.sorted[j].score
+			}
+			return pq.sorted[i].seq < pq.sorted[j].seq
+		})
+		pq.items = nil
+	}
+	item := pq.sorted[0]
+	pq.sorted = pq.sorted[1:]
+	return item.row
+}
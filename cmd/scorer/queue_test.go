@@ -0,0 +1,126 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import "testing"
+
+func drainScores(rq RowQueue) []float64 {
+	var scores []float64
+	for rq.Len() > 0 {
+		row := rq.PopRow()
+		scores = append(scores, row[0].(float64))
+	}
+	return scores
+}
+
+func TestPriorityQueueOrdersByScoreThenSeq(t *testing.T) {
+	var pq PriorityQueue
+	for i, s := range []float64{1, 5, 3, 9, 2, 8, 4} {
+		pq.PushRow([]interface{}{s}, s, i)
+	}
+	got := drainScores(&pq)
+	want := []float64{9, 8, 5, 4, 3, 2, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %v rows, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestPriorityQueueTiesBreakBySeq(t *testing.T) {
+	var pq PriorityQueue
+	// All rows share the same score, so draining must yield them in the
+	// seq order they were pushed in, regardless of push order.
+	seqs := []int{3, 1, 0, 2}
+	for _, seq := range seqs {
+		pq.PushRow([]interface{}{seq}, 5, seq)
+	}
+	var got []int
+	for pq.Len() > 0 {
+		row := pq.PopRow()
+		got = append(got, row[0].(int))
+	}
+	want := []int{0, 1, 2, 3}
+	if len(got) != len(want) {
+		t.Fatalf("got %v rows, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestBoundedPriorityQueueRetainsTopN(t *testing.T) {
+	pq := NewBoundedPriorityQueue(3)
+	for i, s := range []float64{1, 5, 3, 9, 2, 8, 4} {
+		pq.PushRow([]interface{}{s}, s, i)
+	}
+	got := drainScores(pq)
+	want := []float64{9, 8, 5}
+	if len(got) != len(want) {
+		t.Fatalf("got %v rows, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestBoundedPriorityQueueTies(t *testing.T) {
+	pq := NewBoundedPriorityQueue(2)
+	for i, s := range []float64{5, 5, 5, 5} {
+		pq.PushRow([]interface{}{s}, s, i)
+	}
+	got := drainScores(pq)
+	want := []float64{5, 5}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBoundedPriorityQueueLimitLargerThanInput(t *testing.T) {
+	pq := NewBoundedPriorityQueue(100)
+	for i, s := range []float64{3, 1, 2} {
+		pq.PushRow([]interface{}{s}, s, i)
+	}
+	got := drainScores(pq)
+	want := []float64{3, 2, 1}
+	if len(got) != len(want) {
+		t.Fatalf("got %v rows, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestBoundedPriorityQueueZeroLimit(t *testing.T) {
+	pq := NewBoundedPriorityQueue(0)
+	pq.PushRow([]interface{}{1.0}, 1, 0)
+	pq.PushRow([]interface{}{2.0}, 2, 1)
+	if got := pq.Len(); got != 0 {
+		t.Errorf("Len() = %d, want 0", got)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// RecordWriter writes the scored output records to a destination in some
+// on-disk format (e.g. CSV, JSON, JSONL). WriteHeader must be called exactly
+// once, before any call to WriteRecord.
+type RecordWriter interface {
+	// WriteHeader writes the ordered list of output field names.
+	WriteHeader(fields []string) error
+	// WriteRecord writes a single row. values must have the same length
+	// and order as the fields passed to WriteHeader, and each value must
+	// be a string, a float64, or nil.
+	WriteRecord(values []interface{}) error
+	// Flush flushes any output buffered by the writer.
+	Flush() error
+}
+
+// csvRecordWriter writes records as a CSV file, formatting every value as
+// a string, matching the scorer's historic output format.
+type csvRecordWriter struct {
+	w *csv.Writer
+}
+
+// NewCSVRecordWriter returns a RecordWriter that writes comma-separated
+// values to w.
+func NewCSVRecordWriter(w io.Writer) RecordWriter {
+	return &csvRecordWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvRecordWriter) WriteHeader(fields []string) error {
+	return c.w.Write(fields)
+}
+
+func (c *csvRecordWriter) WriteRecord(values []interface{}) error {
+	row := make([]string, len(values))
+	for i, v := range values {
+		row[i] = formatCSVValue(v)
+	}
+	return c.w.Write(row)
+}
+
+func (c *csvRecordWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func formatCSVValue(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return fmt.Sprintf("%.5f", t)
+	default:
+		return fmt.Sprintf("%v", t)
+	}
+}
+
+// jsonRecordWriter writes records as a single JSON array, one object per
+// record, keyed by field name.
+type jsonRecordWriter struct {
+	w       io.Writer
+	fields  []string
+	wrote   bool
+	flushed bool
+}
+
+// NewJSONRecordWriter returns a RecordWriter that writes a JSON array of
+// objects to w.
+func NewJSONRecordWriter(w io.Writer) RecordWriter {
+	return &jsonRecordWriter{w: w}
+}
+
+func (j *jsonRecordWriter) WriteHeader(fields []string) error {
+	j.fields = fields
+	_, err := io.WriteString(j.w, "[")
+	return err
+}
+
+func (j *jsonRecordWriter) WriteRecord(values []interface{}) error {
+	if j.wrote {
+		if _, err := io.WriteString(j.w, ","); err != nil {
+			return err
+		}
+	}
+	j.wrote = true
+	b, err := json.Marshal(recordToMap(j.fields, values))
+	if err != nil {
+		return err
+	}
+	_, err = j.w.Write(b)
+	return err
+}
+
+func (j *jsonRecordWriter) Flush() error {
+	if j.flushed {
+		return nil
+	}
+	j.flushed = true
+	_, err := io.WriteString(j.w, "]")
+	return err
+}
+
+// jsonlRecordWriter writes records as newline-delimited JSON, one object
+// per line, so rows can be streamed to downstream tools without buffering
+// the whole output.
+type jsonlRecordWriter struct {
+	w      io.Writer
+	enc    *json.Encoder
+	fields []string
+}
+
+// NewJSONLRecordWriter returns a RecordWriter that writes one JSON object
+// per line to w.
+func NewJSONLRecordWriter(w io.Writer) RecordWriter {
+	return &jsonlRecordWriter{w: w, enc: json.NewEncoder(w)}
+}
+
+func (j *jsonlRecordWriter) WriteHeader(fields []string) error {
+	j.fields = fields
+	return nil
+}
+
+func (j *jsonlRecordWriter) WriteRecord(values []interface{}) error {
+	return j.enc.Encode(recordToMap(j.fields, values))
+}
+
+func (j *jsonlRecordWriter) Flush() error {
+	return nil
+}
+
+// recordToMap zips fields and values into a JSON-friendly map, preserving
+// float64 values as numbers rather than re-parsing them from strings.
+func recordToMap(fields []string, values []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, len(fields))
+	for i, f := range fields {
+		if i < len(values) {
+			m[f] = values[i]
+		}
+	}
+	return m
+}
+
+// newRecordWriter returns the RecordWriter for the given -format value,
+// writing to w.
+func newRecordWriter(format string, w io.Writer) (RecordWriter, error) {
+	switch format {
+	case "", "csv":
+		return NewCSVRecordWriter(w), nil
+	case "json":
+		return NewJSONRecordWriter(w), nil
+	case "jsonl":
+		return NewJSONLRecordWriter(w), nil
+	default:
+		return nil, fmt.Errorf("unknown output format: %s", format)
+	}
+}
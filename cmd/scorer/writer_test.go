@@ -0,0 +1,177 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestCSVRecordWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewCSVRecordWriter(&buf)
+	if err := w.WriteHeader([]string{"name", "score"}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"foo", 1.5}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"bar", nil}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	want := "name,score\nfoo,1.50000\nbar,\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+}
+
+func TestJSONRecordWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONRecordWriter(&buf)
+	if err := w.WriteHeader([]string{"name", "score"}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"foo", 1.5}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"bar", 2.0}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	var got []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output isn't a JSON array: %v (%q)", err, buf.String())
+	}
+	want := []map[string]interface{}{
+		{"name": "foo", "score": 1.5},
+		{"name": "bar", "score": 2.0},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d records, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i]["name"] != want[i]["name"] || got[i]["score"] != want[i]["score"] {
+			t.Errorf("record %d = %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestJSONLRecordWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLRecordWriter(&buf)
+	if err := w.WriteHeader([]string{"name", "score"}); err != nil {
+		t.Fatalf("WriteHeader: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"foo", 1.5}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.WriteRecord([]interface{}{"bar", 2.0}); err != nil {
+		t.Fatalf("WriteRecord: %v", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	want := []map[string]interface{}{
+		{"name": "foo", "score": 1.5},
+		{"name": "bar", "score": 2.0},
+	}
+	for i, w := range want {
+		var got map[string]interface{}
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("line %d: decode: %v", i, err)
+		}
+		if got["name"] != w["name"] || got["score"] != w["score"] {
+			t.Errorf("line %d = %v, want %v", i, got, w)
+		}
+	}
+}
+
+func TestNewRecordWriterPreservesNumbersVsStrings(t *testing.T) {
+	for _, format := range []string{"json", "jsonl"} {
+		var buf bytes.Buffer
+		w, err := newRecordWriter(format, &buf)
+		if err != nil {
+			t.Fatalf("newRecordWriter(%q): %v", format, err)
+		}
+		if err := w.WriteHeader([]string{"num", "str", "nil"}); err != nil {
+			t.Fatalf("WriteHeader: %v", err)
+		}
+		if err := w.WriteRecord([]interface{}{1.5, "1.5", nil}); err != nil {
+			t.Fatalf("WriteRecord: %v", err)
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("Flush: %v", err)
+		}
+
+		var got map[string]interface{}
+		if format == "json" {
+			var arr []map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &arr); err != nil {
+				t.Fatalf("%s: unmarshal: %v", format, err)
+			}
+			got = arr[0]
+		} else if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+			t.Fatalf("%s: unmarshal: %v", format, err)
+		}
+		if _, ok := got["num"].(float64); !ok {
+			t.Errorf("%s: num = %T(%v), want float64", format, got["num"], got["num"])
+		}
+		if _, ok := got["str"].(string); !ok {
+			t.Errorf("%s: str = %T(%v), want string", format, got["str"], got["str"])
+		}
+		if got["nil"] != nil {
+			t.Errorf("%s: nil = %v, want nil", format, got["nil"])
+		}
+	}
+}
+
+func TestNewRecordWriter(t *testing.T) {
+	tests := []struct {
+		format string
+		want   RecordWriter
+	}{
+		{format: "", want: &csvRecordWriter{}},
+		{format: "csv", want: &csvRecordWriter{}},
+		{format: "json", want: &jsonRecordWriter{}},
+		{format: "jsonl", want: &jsonlRecordWriter{}},
+	}
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		w, err := newRecordWriter(tc.format, &buf)
+		if err != nil {
+			t.Fatalf("newRecordWriter(%q) failed: %v", tc.format, err)
+		}
+		if gotType, wantType := fmt.Sprintf("%T", w), fmt.Sprintf("%T", tc.want); gotType != wantType {
+			t.Errorf("newRecordWriter(%q) = %s, want %s", tc.format, gotType, wantType)
+		}
+	}
+}
+
+func TestNewRecordWriterRejectsUnknownFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := newRecordWriter("xml", &buf); err == nil {
+		t.Error("newRecordWriter(\"xml\") succeeded, want error")
+	}
+}
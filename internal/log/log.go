@@ -0,0 +1,64 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package log is a thin wrapper around zap, used to give every command in
+// this repo a consistent set of -log and -log-env flags.
+package log
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Env selects the zap config used to build a Logger: "dev" for
+// human-readable console output, or "prod" for structured JSON
+// suitable for ingestion.
+type Env string
+
+// DefaultEnv is used when -log-env is not set.
+const DefaultEnv Env = "dev"
+
+// MarshalText implements encoding.TextMarshaler, so Env can be used with
+// flag.TextVar.
+func (e Env) MarshalText() ([]byte, error) {
+	return []byte(e), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, so Env can be used
+// with flag.TextVar.
+func (e *Env) UnmarshalText(text []byte) error {
+	switch Env(text) {
+	case "dev", "prod":
+		*e = Env(text)
+		return nil
+	default:
+		return fmt.Errorf("unknown log env: %s", text)
+	}
+}
+
+// NewLogger builds a zap.Logger configured for env, logging at the given
+// level.
+func NewLogger(env Env, level zapcore.Level) (*zap.Logger, error) {
+	var cfg zap.Config
+	switch env {
+	case "prod":
+		cfg = zap.NewProductionConfig()
+	default:
+		cfg = zap.NewDevelopmentConfig()
+	}
+	cfg.Level = zap.NewAtomicLevelAt(level)
+	return cfg.Build()
+}
@@ -0,0 +1,110 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics provides the Prometheus collectors and structured
+// progress logging shared by long-running row-processing commands, such
+// as scorer and collect_signals, so that multi-hour runs are observable
+// rather than opaque.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+// Metrics is the set of Prometheus collectors a row-processing command
+// updates as it runs.
+type Metrics struct {
+	// RowsRead counts input rows read from the source.
+	RowsRead prometheus.Counter
+	// RowsScored counts rows successfully scored.
+	RowsScored prometheus.Counter
+	// ParseErrors counts fields that failed to parse as a float, labeled
+	// by field name.
+	ParseErrors *prometheus.CounterVec
+	// ScoreLatency is the distribution of per-row scoring latency.
+	ScoreLatency prometheus.Histogram
+	// ScoreValue is the distribution of the final computed score.
+	ScoreValue prometheus.Histogram
+
+	registry *prometheus.Registry
+}
+
+// New creates a Metrics, registering its collectors under namespace
+// (e.g. "scorer" or "collect_signals") with a dedicated registry.
+func New(namespace string) *Metrics {
+	reg := prometheus.NewRegistry()
+	m := &Metrics{
+		RowsRead: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_read_total",
+			Help:      "Number of input rows read.",
+		}),
+		RowsScored: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "rows_scored_total",
+			Help:      "Number of rows successfully scored.",
+		}),
+		ParseErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "field_parse_errors_total",
+			Help:      "Number of fields that failed to parse as a float, by field name.",
+		}, []string{"field"}),
+		ScoreLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "score_latency_seconds",
+			Help:      "Latency of scoring a single row.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		ScoreValue: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "score_value",
+			Help:      "Distribution of the final computed score.",
+			Buckets:   prometheus.LinearBuckets(0, 0.1, 10),
+		}),
+		registry: reg,
+	}
+	reg.MustRegister(m.RowsRead, m.RowsScored, m.ParseErrors, m.ScoreLatency, m.ScoreValue)
+	return m
+}
+
+// Serve starts an HTTP server exposing /metrics on addr, blocking until
+// ctx is canceled or the server fails. A nil or canceled-on-return error
+// from a graceful shutdown is not reported.
+func (m *Metrics) Serve(ctx context.Context, addr string, logger *zap.Logger) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Addr: addr, Handler: mux}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		if err := srv.Shutdown(context.Background()); err != nil {
+			logger.With(zap.Error(err)).Error("Failed to shut down metrics server")
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+)
+
+// freeAddr returns a "host:port" address on an OS-assigned free port,
+// suitable for passing to Metrics.Serve in a test.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func TestMetricsCountersAndHistogramsUpdate(t *testing.T) {
+	m := New("test")
+
+	m.RowsRead.Inc()
+	m.RowsRead.Inc()
+	m.RowsScored.Inc()
+	m.ParseErrors.WithLabelValues("some_field").Inc()
+	m.ScoreLatency.Observe(0.01)
+	m.ScoreValue.Observe(0.5)
+
+	if got := testutil.ToFloat64(m.RowsRead); got != 2 {
+		t.Errorf("RowsRead = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(m.RowsScored); got != 1 {
+		t.Errorf("RowsScored = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.ParseErrors.WithLabelValues("some_field")); got != 1 {
+		t.Errorf("ParseErrors = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ScoreLatency); got != 1 {
+		t.Errorf("ScoreLatency CollectAndCount = %d, want 1", got)
+	}
+	if got := testutil.CollectAndCount(m.ScoreValue); got != 1 {
+		t.Errorf("ScoreValue CollectAndCount = %d, want 1", got)
+	}
+}
+
+func TestMetricsServeExposesMetricNames(t *testing.T) {
+	m := New("test")
+	m.RowsRead.Inc()
+	m.RowsScored.Inc()
+	m.ParseErrors.WithLabelValues("some_field").Inc()
+	m.ScoreLatency.Observe(0.01)
+	m.ScoreValue.Observe(0.5)
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Serve blocks, so run it in the background and poll until it's
+	// accepting connections.
+	errCh := make(chan error, 1)
+	go func() { errCh <- m.Serve(ctx, addr, zap.NewNop()) }()
+
+	url := fmt.Sprintf("http://%s/metrics", addr)
+	var resp *http.Response
+	var err error
+	for i := 0; i < 50; i++ {
+		resp, err = http.Get(url)
+		if err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("GET /metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading response body: %v", err)
+	}
+	text := string(body)
+	for _, want := range []string{
+		"test_rows_read_total",
+		"test_rows_scored_total",
+		"test_field_parse_errors_total",
+		"test_score_latency_seconds",
+		"test_score_value",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("/metrics output missing %q:\n%s", want, text)
+		}
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Progress logs structured progress for a long-running row-processing
+// loop, whenever the row count reaches a multiple of `every`, and whenever
+// `interval` of wall-clock time has passed since the last progress line
+// (either may be zero to disable that trigger). Inc is safe to call from
+// multiple goroutines, e.g. a pool of scoring workers.
+type Progress struct {
+	logger   *zap.Logger
+	every    int64
+	interval time.Duration
+	total    int64
+	start    time.Time
+
+	mu      sync.Mutex
+	count   int64
+	lastLog time.Time
+}
+
+// NewProgress returns a Progress that logs to logger. total is the
+// expected number of rows, used to log an eta; pass 0 if it isn't known
+// ahead of time.
+func NewProgress(logger *zap.Logger, every int64, interval time.Duration, total int64) *Progress {
+	now := time.Now()
+	return &Progress{logger: logger, every: every, interval: interval, total: total, start: now, lastLog: now}
+}
+
+// Inc records that one more row has been processed, logging a progress
+// line if the count is now a multiple of `every`, or if `interval` has
+// elapsed since the last progress line.
+func (p *Progress) Inc() {
+	p.mu.Lock()
+	p.count++
+	n := p.count
+	due := (p.every > 0 && n%p.every == 0) || (p.interval > 0 && time.Since(p.lastLog) >= p.interval)
+	if due {
+		p.lastLog = time.Now()
+	}
+	p.mu.Unlock()
+
+	if due {
+		elapsed := time.Since(p.start).Seconds()
+		rowsPerSec := float64(n) / elapsed
+		fields := []zap.Field{
+			zap.Int64("rows_processed", n),
+			zap.Float64("rows_per_sec", rowsPerSec),
+		}
+		if p.total > 0 && rowsPerSec > 0 {
+			eta := time.Duration(float64(p.total-n) / rowsPerSec * float64(time.Second))
+			fields = append(fields, zap.Duration("eta", eta))
+		}
+		p.logger.With(fields...).Info("Scoring progress")
+	}
+}
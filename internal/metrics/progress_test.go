@@ -0,0 +1,73 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestProgressLogsEveryNRows(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	p := NewProgress(logger, 3, 0, 0)
+	for i := 0; i < 7; i++ {
+		p.Inc()
+	}
+	if got := logs.Len(); got != 2 {
+		t.Fatalf("got %d log entries, want 2", got)
+	}
+}
+
+func TestProgressLogsAreConcurrencySafe(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	p := NewProgress(logger, 10, 0, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.Inc()
+		}()
+	}
+	wg.Wait()
+	if got := logs.Len(); got != 10 {
+		t.Errorf("got %d log entries, want 10", got)
+	}
+}
+
+func TestProgressLogsIncludeETAWhenTotalKnown(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	p := NewProgress(logger, 1, 0, 100)
+	time.Sleep(time.Millisecond)
+	p.Inc()
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+	if _, ok := entries[0].ContextMap()["eta"]; !ok {
+		t.Errorf("expected an eta field in %v", entries[0].ContextMap())
+	}
+}
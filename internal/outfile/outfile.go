@@ -0,0 +1,72 @@
+// Copyright 2022 Criticality Score Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     https://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outfile helps commands open an output file (or stdout) for
+// writing, guarding against accidentally clobbering an existing file.
+package outfile
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	forceFlag  *bool
+	appendFlag *bool
+)
+
+// DefineFlags registers the -force and -append flags (using the supplied
+// names) on fs. fileHelpName is the name the -force usage text gives the
+// output file.
+func DefineFlags(fs *flag.FlagSet, forceFlagName, appendFlagName, fileHelpName string) {
+	forceFlag = fs.Bool(forceFlagName, false, fmt.Sprintf("overwrite %s if it already exists.", fileHelpName))
+	appendFlag = fs.Bool(appendFlagName, false, "append to the output file if it already exists.")
+}
+
+// Open opens filename for writing, returning an io.WriteCloser. If
+// filename is "-", it writes to stdout and closing it is a no-op.
+//
+// Opening an existing file fails unless -force or -append was set via
+// DefineFlags.
+func Open(ctx context.Context, filename string) (io.WriteCloser, error) {
+	if filename == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE
+	switch {
+	case appendFlag != nil && *appendFlag:
+		flags |= os.O_APPEND
+	case forceFlag != nil && *forceFlag:
+		flags |= os.O_TRUNC
+	default:
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(filename, flags, 0o644)
+	if err != nil {
+		if os.IsExist(err) {
+			return nil, fmt.Errorf("%s already exists: use -force to overwrite or -append to append: %w", filename, err)
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }